@@ -0,0 +1,340 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zhufuyi/logger"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// ClusterPool talks to a Redis Cluster. It keeps one *redis.Pool per node and
+// a slot -> node routing table that is refreshed from CLUSTER SLOTS, both on
+// init and whenever a command is redirected with MOVED. An ASK redirect is
+// followed for that one command only and leaves the table as it is.
+type ClusterPool struct {
+	password string
+	opts     *poolOptions
+
+	mu    sync.RWMutex
+	nodes map[string]*redis.Pool // "host:port" -> pool
+	slots [16384]string          // slot -> "host:port"
+}
+
+var clusterPool *ClusterPool
+
+// NewRedisPoolCluster connects to a Redis Cluster using addrs as seed nodes
+// and builds the initial slot routing table from CLUSTER SLOTS.
+func NewRedisPoolCluster(addrs []string, password string, opts ...Option) error {
+	o := defaultPoolOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cp := &ClusterPool{
+		password: password,
+		opts:     o,
+		nodes:    make(map[string]*redis.Pool),
+	}
+
+	for _, addr := range addrs {
+		cp.nodePool(addr)
+	}
+
+	if err := cp.refreshSlots(); err != nil {
+		return err
+	}
+
+	clusterPool = cp
+	return nil
+}
+
+// nodePool returns the pool for addr, creating it on first use.
+func (c *ClusterPool) nodePool(addr string) *redis.Pool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.nodes[addr]; ok {
+		return p
+	}
+
+	o := c.opts
+	password := c.password
+	p := &redis.Pool{
+		MaxIdle:     o.maxIdle,
+		MaxActive:   o.maxActive,
+		IdleTimeout: o.idleTimeout,
+		Wait:        o.wait,
+		Dial: func() (redis.Conn, error) {
+			dialOpts := []redis.DialOption{
+				redis.DialConnectTimeout(o.dialTimeout),
+				redis.DialReadTimeout(o.readTimeout),
+				redis.DialWriteTimeout(o.writeTimeout),
+			}
+			if password != "" {
+				dialOpts = append(dialOpts, redis.DialPassword(password))
+			}
+			if o.username != "" {
+				dialOpts = append(dialOpts, redis.DialUsername(o.username))
+			}
+			if o.tlsConfig != nil {
+				dialOpts = append(dialOpts, redis.DialUseTLS(true), redis.DialTLSConfig(o.tlsConfig))
+			}
+
+			return redis.Dial("tcp", addr, dialOpts...)
+		},
+		TestOnBorrow: func(conn redis.Conn, t time.Time) error {
+			_, err := conn.Do("PING")
+			return err
+		},
+	}
+
+	c.nodes[addr] = p
+	return p
+}
+
+// refreshSlots rebuilds the slot -> node table from CLUSTER SLOTS, asking
+// whichever known node answers first.
+func (c *ClusterPool) refreshSlots() error {
+	c.mu.RLock()
+	addrs := make([]string, 0, len(c.nodes))
+	for addr := range c.nodes {
+		addrs = append(addrs, addr)
+	}
+	c.mu.RUnlock()
+
+	var lastErr error
+	for _, addr := range addrs {
+		conn := c.nodePool(addr).Get()
+		reply, err := redis.Values(conn.Do("CLUSTER", "SLOTS"))
+		conn.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		var slots [16384]string
+		for _, slotRaw := range reply {
+			slotInfo, err := redis.Values(slotRaw, nil)
+			if err != nil || len(slotInfo) < 3 {
+				continue
+			}
+			start, _ := redis.Int(slotInfo[0], nil)
+			end, _ := redis.Int(slotInfo[1], nil)
+			hostPort, err := redis.Values(slotInfo[2], nil)
+			if err != nil || len(hostPort) < 2 {
+				continue
+			}
+			host, _ := redis.String(hostPort[0], nil)
+			port, _ := redis.Int(hostPort[1], nil)
+			nodeAddr := fmt.Sprintf("%s:%d", host, port)
+			c.nodePool(nodeAddr)
+			for slot := start; slot <= end && slot < 16384; slot++ {
+				slots[slot] = nodeAddr
+			}
+		}
+
+		c.mu.Lock()
+		c.slots = slots
+		c.mu.Unlock()
+		return nil
+	}
+
+	return fmt.Errorf("redis cluster: unable to refresh slots from any known node: %w", lastErr)
+}
+
+// GetClusterConn returns a connection to an arbitrary cluster node. Prefer
+// GetClusterConnForKey when the following commands operate on a specific key.
+//
+// Named GetClusterConn rather than GetConn (and GetClusterConnForKey rather
+// than GetConnForKey) to avoid colliding with the package-level GetConn,
+// which is already taken by the single-node pool.
+func GetClusterConn() (RedisConn, error) {
+	if clusterPool == nil {
+		logger.Panic("redis cluster pool is nil, go to connect redis first, eg: redis.NewRedisPoolCluster(addrs, password)")
+	}
+
+	clusterPool.mu.RLock()
+	defer clusterPool.mu.RUnlock()
+	for _, p := range clusterPool.nodes {
+		return &clusterRedisConn{DefaultRedisConn: DefaultRedisConn{Conn: p.Get()}, cluster: clusterPool}, nil
+	}
+	return nil, fmt.Errorf("redis cluster: no nodes available")
+}
+
+// GetClusterConnForKey returns a connection to the node that owns key's slot,
+// taking the "{hashtag}" convention into account.
+func GetClusterConnForKey(key string) (RedisConn, error) {
+	if clusterPool == nil {
+		logger.Panic("redis cluster pool is nil, go to connect redis first, eg: redis.NewRedisPoolCluster(addrs, password)")
+	}
+
+	slot := keySlot(key)
+	clusterPool.mu.RLock()
+	addr := clusterPool.slots[slot]
+	clusterPool.mu.RUnlock()
+	if addr == "" {
+		return nil, fmt.Errorf("redis cluster: no node known for slot %d", slot)
+	}
+
+	conn := clusterPool.nodePool(addr).Get()
+	return &clusterRedisConn{DefaultRedisConn: DefaultRedisConn{Conn: conn}, cluster: clusterPool}, nil
+}
+
+// clusterRedisConn wraps DefaultRedisConn and transparently follows the
+// MOVED/ASK redirections a Redis Cluster node may reply with. Do and
+// DoContext detect a redirect from their own reply; Send only buffers a
+// command; the redirect for a buffered Send is instead caught and replayed
+// by the Receive that reads its reply (see pending below).
+type clusterRedisConn struct {
+	DefaultRedisConn
+	cluster *ClusterPool
+
+	// pending holds the command Send last wrote to the output buffer, so
+	// the matching Receive can detect a MOVED/ASK reply and replay it on
+	// the right node. Only one command may be in flight at a time; a
+	// cluster conn doesn't support pipelining several commands ahead of
+	// their Receives (see Send below).
+	pending     bool
+	pendingCmd  string
+	pendingArgs []interface{}
+}
+
+// WithLog is overridden so it returns the clusterRedisConn wrapper itself
+// rather than the promoted *DefaultRedisConn; without this, callers doing
+// conn = conn.WithLog() would silently lose MOVED/ASK redirect handling for
+// the rest of the connection's life.
+func (c *clusterRedisConn) WithLog() RedisConn {
+	c.DefaultRedisConn.WithLog()
+	return c
+}
+
+func (c *clusterRedisConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	reply, err := c.DefaultRedisConn.Do(commandName, args...)
+	return c.redirect(err, reply, func() (interface{}, error) {
+		return c.DefaultRedisConn.Do(commandName, args...)
+	})
+}
+
+func (c *clusterRedisConn) DoContext(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	reply, err := c.DefaultRedisConn.DoContext(ctx, commandName, args...)
+	return c.redirect(err, reply, func() (interface{}, error) {
+		return c.DefaultRedisConn.DoContext(ctx, commandName, args...)
+	})
+}
+
+// Send buffers the command like the plain redigo Conn.Send does; it does
+// NOT read a reply, so it can't detect a MOVED/ASK error itself. Instead it
+// remembers the command so the matching Receive can detect the redirect and
+// replay it on the right node. Only one command may be outstanding at a
+// time: since a cluster conn may need to swap to a different underlying
+// connection mid-pipeline to follow a redirect, it can't safely buffer
+// several commands ahead of their Receives the way a normal redigo Conn
+// can. Callers that need real pipelining against a cluster should route
+// commands by key and use Do.
+func (c *clusterRedisConn) Send(commandName string, args ...interface{}) error {
+	if c.pending {
+		return fmt.Errorf("redis cluster: Send called again before the previous command's Receive; cluster conns don't support pipelining more than one outstanding command")
+	}
+
+	if err := c.DefaultRedisConn.Send(commandName, args...); err != nil {
+		return err
+	}
+
+	c.pending, c.pendingCmd, c.pendingArgs = true, commandName, args
+	return nil
+}
+
+func (c *clusterRedisConn) SendContext(ctx context.Context, commandName string, args ...interface{}) error {
+	if c.pending {
+		return fmt.Errorf("redis cluster: Send called again before the previous command's Receive; cluster conns don't support pipelining more than one outstanding command")
+	}
+
+	if err := c.DefaultRedisConn.SendContext(ctx, commandName, args...); err != nil {
+		return err
+	}
+
+	c.pending, c.pendingCmd, c.pendingArgs = true, commandName, args
+	return nil
+}
+
+// Receive reads the reply to the command Send last buffered, transparently
+// following a MOVED/ASK redirect by resending that same command on the
+// right node.
+func (c *clusterRedisConn) Receive() (interface{}, error) {
+	reply, err := c.DefaultRedisConn.Receive()
+	return c.receiveRedirect(reply, err)
+}
+
+func (c *clusterRedisConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	reply, err := c.DefaultRedisConn.ReceiveContext(ctx)
+	return c.receiveRedirect(reply, err)
+}
+
+func (c *clusterRedisConn) receiveRedirect(reply interface{}, err error) (interface{}, error) {
+	if !c.pending {
+		return reply, err
+	}
+
+	commandName, args := c.pendingCmd, c.pendingArgs
+	c.pending, c.pendingCmd, c.pendingArgs = false, "", nil
+
+	return c.redirect(err, reply, func() (interface{}, error) {
+		if err := c.DefaultRedisConn.Send(commandName, args...); err != nil {
+			return nil, err
+		}
+		if err := c.DefaultRedisConn.Flush(); err != nil {
+			return nil, err
+		}
+		return c.DefaultRedisConn.Receive()
+	})
+}
+
+// redirect follows at most one MOVED/ASK redirection for the error returned
+// by an already-attempted call, swapping c's underlying connection to the
+// right node and invoking retry once. If err isn't a redirect, reply/err are
+// returned unchanged.
+func (c *clusterRedisConn) redirect(err error, reply interface{}, retry func() (interface{}, error)) (interface{}, error) {
+	redisErr, ok := err.(redis.Error)
+	if !ok {
+		return reply, err
+	}
+
+	if addr, ok := parseRedirect(string(redisErr), "MOVED"); ok {
+		_ = c.cluster.refreshSlots()
+		c.swapConn(addr)
+		return retry()
+	}
+
+	if addr, ok := parseRedirect(string(redisErr), "ASK"); ok {
+		c.swapConn(addr)
+		if _, err := c.Conn.Do("ASKING"); err != nil {
+			return nil, err
+		}
+		return retry()
+	}
+
+	return reply, err
+}
+
+// swapConn points c at a fresh connection to addr, closing the one it was
+// using before.
+func (c *clusterRedisConn) swapConn(addr string) {
+	old := c.Conn
+	c.Conn = c.cluster.nodePool(addr).Get()
+	old.Close()
+}
+
+// parseRedirect extracts the target "host:port" out of a "MOVED <slot> <addr>"
+// or "ASK <slot> <addr>" error reply.
+func parseRedirect(msg, kind string) (string, bool) {
+	fields := strings.Fields(msg)
+	if len(fields) != 3 || fields[0] != kind {
+		return "", false
+	}
+	return fields[2], true
+}
@@ -0,0 +1,175 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+func TestCRC16KnownVector(t *testing.T) {
+	// CRC-16/XMODEM check value for "123456789", the same polynomial and
+	// init value Redis Cluster uses for key hashing.
+	got := crc16("123456789")
+	want := uint16(0x31C3)
+	if got != want {
+		t.Fatalf("crc16(\"123456789\") = 0x%04X, want 0x%04X", got, want)
+	}
+}
+
+func TestKeySlotHashtagExtraction(t *testing.T) {
+	// Keys sharing a "{hashtag}" must land on the same slot regardless of
+	// what surrounds the hashtag.
+	a := keySlot("{user1000}.following")
+	b := keySlot("{user1000}.followers")
+	if a != b {
+		t.Fatalf("keys sharing {user1000} landed on different slots: %d vs %d", a, b)
+	}
+
+	c := keySlot("foo{bar}baz")
+	d := keySlot("bar")
+	if c != d {
+		t.Fatalf("foo{bar}baz should hash as bar, got slots %d vs %d", c, d)
+	}
+
+	// Keys without a hashtag are hashed as-is, so unrelated keys normally
+	// land on different slots.
+	if keySlot("unrelated-key-one") == keySlot("unrelated-key-two") {
+		t.Fatalf("expected different slots for unrelated keys (this can rarely collide by chance)")
+	}
+}
+
+func TestParseRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		msg      string
+		kind     string
+		wantAddr string
+		wantOK   bool
+	}{
+		{"moved", "MOVED 3999 127.0.0.1:6381", "MOVED", "127.0.0.1:6381", true},
+		{"ask", "ASK 3999 127.0.0.1:6381", "ASK", "127.0.0.1:6381", true},
+		{"wrong kind", "MOVED 3999 127.0.0.1:6381", "ASK", "", false},
+		{"not a redirect", "WRONGTYPE Operation against a key", "MOVED", "", false},
+		{"malformed", "MOVED 3999", "MOVED", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, ok := parseRedirect(tt.msg, tt.kind)
+			if ok != tt.wantOK || addr != tt.wantAddr {
+				t.Fatalf("parseRedirect(%q, %q) = (%q, %v), want (%q, %v)",
+					tt.msg, tt.kind, addr, ok, tt.wantAddr, tt.wantOK)
+			}
+		})
+	}
+}
+
+// fakeNodeConn is a minimal redis.Conn standing in for a cluster node. respond
+// decides the reply/err for a given command; Send stashes that result so a
+// following Receive can return it, matching how a real connection defers the
+// reply until Receive is called.
+type fakeNodeConn struct {
+	calls        []string
+	respond      func(cmd string, args []interface{}) (interface{}, error)
+	pendingReply interface{}
+	pendingErr   error
+}
+
+func (f *fakeNodeConn) Do(cmd string, args ...interface{}) (interface{}, error) {
+	f.calls = append(f.calls, cmd)
+	return f.respond(cmd, args)
+}
+
+func (f *fakeNodeConn) Send(cmd string, args ...interface{}) error {
+	f.calls = append(f.calls, cmd)
+	f.pendingReply, f.pendingErr = f.respond(cmd, args)
+	return nil
+}
+
+func (f *fakeNodeConn) Flush() error                  { return nil }
+func (f *fakeNodeConn) Receive() (interface{}, error) { return f.pendingReply, f.pendingErr }
+func (f *fakeNodeConn) Close() error                  { return nil }
+func (f *fakeNodeConn) Err() error                    { return nil }
+
+// newRedirectingCluster builds a ClusterPool whose two nodes are fakeNodeConns
+// reachable without any real network I/O: node1 answers "GET" with a MOVED
+// redirect to addr2, node2 answers it with a normal reply.
+func newRedirectingCluster(t *testing.T) (cp *ClusterPool, node1, node2 *fakeNodeConn) {
+	t.Helper()
+
+	node1 = &fakeNodeConn{respond: func(cmd string, args []interface{}) (interface{}, error) {
+		if cmd == "GET" {
+			return nil, redis.Error("MOVED 1000 addr2")
+		}
+		return nil, fmt.Errorf("fakeNodeConn(node1): unexpected command %q", cmd)
+	}}
+	node2 = &fakeNodeConn{respond: func(cmd string, args []interface{}) (interface{}, error) {
+		switch cmd {
+		case "GET":
+			return "bar", nil
+		case "CLUSTER":
+			return nil, fmt.Errorf("fakeNodeConn(node2): CLUSTER SLOTS not supported")
+		default:
+			return nil, fmt.Errorf("fakeNodeConn(node2): unexpected command %q", cmd)
+		}
+	}}
+
+	cp = &ClusterPool{nodes: map[string]*redis.Pool{
+		"addr1": {Dial: func() (redis.Conn, error) { return node1, nil }},
+		"addr2": {Dial: func() (redis.Conn, error) { return node2, nil }},
+	}}
+	return cp, node1, node2
+}
+
+func TestClusterRedisConnDoFollowsMovedRedirect(t *testing.T) {
+	cp, _, node2 := newRedirectingCluster(t)
+	c1, _ := cp.nodes["addr1"].Dial()
+	conn := &clusterRedisConn{DefaultRedisConn: DefaultRedisConn{Conn: c1}, cluster: cp}
+
+	reply, err := conn.Do("GET", "foo")
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if reply != "bar" {
+		t.Fatalf("Do returned %v, want %q", reply, "bar")
+	}
+	if len(node2.calls) == 0 || node2.calls[len(node2.calls)-1] != "GET" {
+		t.Fatalf("expected the redirected node to receive the replayed GET, got calls %v", node2.calls)
+	}
+}
+
+func TestClusterRedisConnSendReceiveFollowsMovedRedirect(t *testing.T) {
+	cp, _, node2 := newRedirectingCluster(t)
+	c1, _ := cp.nodes["addr1"].Dial()
+	conn := &clusterRedisConn{DefaultRedisConn: DefaultRedisConn{Conn: c1}, cluster: cp}
+
+	if err := conn.Send("GET", "foo"); err != nil {
+		t.Fatalf("Send returned error: %v", err)
+	}
+
+	reply, err := conn.Receive()
+	if err != nil {
+		t.Fatalf("Receive returned error: %v", err)
+	}
+	if reply != "bar" {
+		t.Fatalf("Receive returned %v, want %q", reply, "bar")
+	}
+	if len(node2.calls) == 0 || node2.calls[len(node2.calls)-1] != "GET" {
+		t.Fatalf("expected the redirected node to receive the replayed GET, got calls %v", node2.calls)
+	}
+}
+
+func TestClusterRedisConnSendRejectsSecondSendBeforeReceive(t *testing.T) {
+	cp, _, _ := newRedirectingCluster(t)
+	c1, _ := cp.nodes["addr1"].Dial()
+	conn := &clusterRedisConn{DefaultRedisConn: DefaultRedisConn{Conn: c1}, cluster: cp}
+
+	if err := conn.Send("GET", "foo"); err != nil {
+		t.Fatalf("first Send returned error: %v", err)
+	}
+
+	if err := conn.Send("GET", "bar"); err == nil {
+		t.Fatalf("second Send before Receive should be rejected, not silently accepted")
+	}
+}
@@ -0,0 +1,174 @@
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+// DoContext sends a command and waits for its reply, honouring ctx: a
+// deadline on ctx becomes a per-command read deadline on the underlying
+// socket (via redigo's ConnWithTimeout), and cancelling ctx
+// aborts the command by closing the connection, which is the only way to
+// interrupt a blocking command already in flight (BLPOP, XREAD, SUBSCRIBE,
+// ...). Do delegates here with context.Background() to preserve its
+// existing behavior.
+func (d *DefaultRedisConn) DoContext(ctx context.Context, commandName string, args ...interface{}) (reply interface{}, err error) {
+	stop := d.watchContext(ctx)
+	defer stop()
+
+	observe := observabilityEnabled()
+	var start time.Time
+	if observe {
+		start = time.Now()
+	}
+
+	result, err := d.doWithDeadline(ctx, commandName, args...)
+
+	if observe {
+		observeCommand(commandName, args, start, err)
+	}
+
+	if err != nil {
+		if d.printLog {
+			d.printLog = false
+			printError(err, "redis do error", commandName, args...)
+		}
+		return result, err
+	}
+
+	if d.printLog {
+		d.printLog = false
+		printInfo(result, "redis do", commandName, args...)
+	}
+
+	return result, err
+}
+
+func (d *DefaultRedisConn) doWithDeadline(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if cwt, ok := d.Conn.(redis.ConnWithTimeout); ok {
+			return cwt.DoWithTimeout(time.Until(deadline), commandName, args...)
+		}
+	}
+	return d.Conn.Do(commandName, args...)
+}
+
+// SendContext writes the command to the client's output buffer. A deadline
+// on ctx is not applied, since nothing is read here; if ctx is done while it
+// runs, the connection is closed as in DoContext. Send delegates here with
+// context.Background().
+func (d *DefaultRedisConn) SendContext(ctx context.Context, commandName string, args ...interface{}) error {
+	stop := d.watchContext(ctx)
+	defer stop()
+
+	observe := observabilityEnabled()
+	var start time.Time
+	if observe {
+		start = time.Now()
+	}
+
+	err := d.Conn.Send(commandName, args...)
+
+	if observe {
+		observeCommand(commandName, args, start, err)
+	}
+
+	if err != nil {
+		if d.printLog {
+			d.printLog = false
+			printError(err, "redis send error", commandName, args...)
+		}
+		return err
+	}
+
+	if d.printLog {
+		d.printLog = false
+		printInfo(nil, "redis send", commandName, args...)
+	}
+
+	return err
+}
+
+// ReceiveContext receives a single reply from the Redis server, honouring
+// ctx the same way DoContext does. Receive delegates here with
+// context.Background().
+func (d *DefaultRedisConn) ReceiveContext(ctx context.Context) (reply interface{}, err error) {
+	stop := d.watchContext(ctx)
+	defer stop()
+
+	observe := observabilityEnabled()
+	var start time.Time
+	if observe {
+		start = time.Now()
+	}
+
+	result, err := d.receiveWithDeadline(ctx)
+
+	if observe {
+		observeCommand("", nil, start, err)
+	}
+
+	if err != nil {
+		if d.printLog {
+			d.printLog = false
+			printError(err, "redis receive error", "")
+		}
+		return result, err
+	}
+
+	if d.printLog {
+		d.printLog = false
+		printInfo(result, "redis receive", "")
+	}
+	return result, err
+}
+
+func (d *DefaultRedisConn) receiveWithDeadline(ctx context.Context) (interface{}, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if cwt, ok := d.Conn.(redis.ConnWithTimeout); ok {
+			return cwt.ReceiveWithTimeout(time.Until(deadline))
+		}
+	}
+	return d.Conn.Receive()
+}
+
+// GetConnContext behaves like GetConn, but the returned connection is closed
+// as soon as ctx is done, aborting any command still in flight on it. Use
+// this in HTTP handlers and other request-scoped code so client
+// cancellation propagates to Redis I/O.
+func GetConnContext(ctx context.Context) (RedisConn, error) {
+	conn, err := GetConn()
+	if err != nil {
+		return nil, err
+	}
+
+	// The watcher goroutine exits once ctx is done, or as soon as the
+	// returned conn is Close()d, whichever happens first.
+	if d, ok := conn.(*DefaultRedisConn); ok {
+		d.contextStop = d.watchContext(ctx)
+	}
+
+	return conn, nil
+}
+
+// watchContext closes d once ctx is done, unless stop is called first. It's
+// how ctx cancellation interrupts a blocking command that's already in
+// flight, since redigo has no other way to abort one.
+func (d *DefaultRedisConn) watchContext(ctx context.Context) (stop func()) {
+	if ctx == nil || ctx.Done() == nil {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			d.Close()
+		case <-done:
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -0,0 +1,32 @@
+package redis
+
+import "strings"
+
+// crc16 implements the CRC16 (CCITT/XMODEM, poly 0x1021) checksum that Redis
+// Cluster uses to map keys onto the 16384 hash slots.
+// See https://redis.io/docs/reference/cluster-spec/#key-distribution-model
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = (crc << 1) ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// keySlot computes the cluster slot for key, honouring the "{hashtag}"
+// convention that lets callers force related keys onto the same slot.
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key)) % 16384
+}
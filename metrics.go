@@ -0,0 +1,133 @@
+package redis
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/zhufuyi/logger"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	commandDuration atomic.Pointer[prometheus.HistogramVec]
+	commandErrors   atomic.Pointer[prometheus.CounterVec]
+
+	slowThreshold atomic.Value // time.Duration
+	cmdTracer     atomic.Value // tracerFunc
+)
+
+// tracerFunc boxes the SetTracer callback so atomic.Value, which requires a
+// consistent concrete type across Store calls, can hold a possibly-nil func.
+type tracerFunc struct {
+	fn func(cmd string, args []interface{}, dur time.Duration, err error)
+}
+
+// RegisterMetrics publishes this package's pool and command metrics on reg:
+// pool gauges sampled from pool.Stats() (redis_pool_active, redis_pool_idle,
+// redis_pool_wait_count), a redis_command_duration_seconds histogram
+// labelled by command name, and a redis_command_errors_total counter.
+func RegisterMetrics(reg prometheus.Registerer) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "redis_command_duration_seconds",
+		Help: "Duration of redis commands, labelled by command name.",
+	}, []string{"command"})
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_command_errors_total",
+		Help: "Total number of redis commands that returned an error, labelled by command name.",
+	}, []string{"command"})
+
+	reg.MustRegister(duration, errors)
+
+	commandDuration.Store(duration)
+	commandErrors.Store(errors)
+
+	reg.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "redis_pool_active",
+			Help: "Number of connections currently allocated by the pool.",
+		}, func() float64 { return float64(poolStats().ActiveCount) }),
+
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "redis_pool_idle",
+			Help: "Number of idle connections in the pool.",
+		}, func() float64 { return float64(poolStats().IdleCount) }),
+
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "redis_pool_wait_count",
+			Help: "Total number of connections callers have had to wait for because the pool was at MaxActive.",
+		}, func() float64 { return float64(poolStats().WaitCount) }),
+	)
+}
+
+func poolStats() redisPoolStats {
+	if pool == nil {
+		return redisPoolStats{}
+	}
+	s := pool.Stats()
+	return redisPoolStats{ActiveCount: s.ActiveCount, IdleCount: s.IdleCount, WaitCount: s.WaitCount}
+}
+
+// redisPoolStats is the subset of redis.PoolStats this package samples,
+// kept separate so callers of poolStats don't need to import redigo.
+type redisPoolStats struct {
+	ActiveCount int
+	IdleCount   int
+	WaitCount   int64
+}
+
+// SetSlowThreshold makes every command slower than d get logged as a slow
+// command. Zero (the default) disables slow-command logging.
+func SetSlowThreshold(d time.Duration) {
+	slowThreshold.Store(d)
+}
+
+// SetTracer installs fn to be called after every command with its name,
+// args, duration and error, letting callers hook OpenTelemetry spans or emit
+// their own structured slow-query logs. Pass nil to remove it.
+func SetTracer(fn func(cmd string, args []interface{}, dur time.Duration, err error)) {
+	cmdTracer.Store(tracerFunc{fn})
+}
+
+// observabilityEnabled reports whether any metrics, tracer or slow-threshold
+// has been configured. DoContext/SendContext/ReceiveContext check this
+// before paying for a time.Now() call, so the fast path costs nothing when
+// observability isn't in use.
+func observabilityEnabled() bool {
+	return commandDuration.Load() != nil || getTracer() != nil || getSlowThreshold() > 0
+}
+
+func getTracer() func(cmd string, args []interface{}, dur time.Duration, err error) {
+	t, _ := cmdTracer.Load().(tracerFunc)
+	return t.fn
+}
+
+func getSlowThreshold() time.Duration {
+	d, _ := slowThreshold.Load().(time.Duration)
+	return d
+}
+
+// observeCommand records metrics/tracing for a single command that started
+// at start. Called only when observabilityEnabled() already returned true.
+func observeCommand(commandName string, args []interface{}, start time.Time, err error) {
+	dur := time.Since(start)
+
+	if duration := commandDuration.Load(); duration != nil {
+		duration.WithLabelValues(commandName).Observe(dur.Seconds())
+		if errs := commandErrors.Load(); err != nil && errs != nil {
+			errs.WithLabelValues(commandName).Inc()
+		}
+	}
+
+	if threshold := getSlowThreshold(); threshold > 0 && dur >= threshold {
+		logger.Warn("redis slow command",
+			logger.String("command", commandName),
+			logger.Any("args", args),
+			logger.Any("duration", dur),
+		)
+	}
+
+	if fn := getTracer(); fn != nil {
+		fn(commandName, args, dur, err)
+	}
+}
@@ -0,0 +1,199 @@
+package redis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// unlockScript deletes the lock key only if it still holds the token this
+// client set, so a client can never release a lock it doesn't own anymore
+// (e.g. one that expired and was re-acquired by someone else).
+var unlockScript = NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+else
+	return 0
+end
+`)
+
+// extendScript resets the expiry of the lock key only if it still holds the
+// token this client set.
+var extendScript = NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+else
+	return 0
+end
+`)
+
+// mutexOptions carries the tunable parameters accepted by NewMutex.
+type mutexOptions struct {
+	expiry       time.Duration
+	tries        int
+	delay        time.Duration
+	valueFactory func() string
+}
+
+func defaultMutexOptions() *mutexOptions {
+	return &mutexOptions{
+		expiry:       8 * time.Second,
+		tries:        16,
+		delay:        200 * time.Millisecond,
+		valueFactory: randomToken,
+	}
+}
+
+// MutexOption configures a Mutex created by NewMutex.
+type MutexOption func(*mutexOptions)
+
+// WithExpiry sets the lock's TTL, i.e. how long it's held before it expires
+// on its own if the owner never calls Unlock or Extend.
+func WithExpiry(d time.Duration) MutexOption {
+	return func(o *mutexOptions) { o.expiry = d }
+}
+
+// WithTries sets how many times Lock attempts to acquire the lock before
+// giving up.
+func WithTries(n int) MutexOption {
+	return func(o *mutexOptions) { o.tries = n }
+}
+
+// WithDelay sets the base delay between Lock attempts. Each attempt jitters
+// this value to avoid retry storms across competing clients.
+func WithDelay(d time.Duration) MutexOption {
+	return func(o *mutexOptions) { o.delay = d }
+}
+
+// WithValueFactory overrides how the lock's random token is generated.
+func WithValueFactory(f func() string) MutexOption {
+	return func(o *mutexOptions) { o.valueFactory = f }
+}
+
+// Mutex is a Redlock-style distributed lock built on top of this package's
+// pool and Script helper.
+type Mutex struct {
+	name  string
+	value string
+	opts  *mutexOptions
+}
+
+// NewMutex creates a Mutex guarding name. The lock isn't acquired until Lock
+// is called.
+func NewMutex(name string, opts ...MutexOption) *Mutex {
+	o := defaultMutexOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Mutex{name: name, opts: o}
+}
+
+// Lock acquires the lock, retrying with a jittered delay up to opts.tries
+// times. It gives up and returns ctx.Err() if ctx is done first.
+func (m *Mutex) Lock(ctx context.Context) error {
+	value := m.opts.valueFactory()
+
+	for i := 0; i < m.opts.tries; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(jitter(m.opts.delay)):
+			}
+		}
+
+		ok, err := m.tryLock(value)
+		if err != nil {
+			return err
+		}
+		if ok {
+			m.value = value
+			return nil
+		}
+	}
+
+	return fmt.Errorf("redis: failed to acquire lock %q after %d tries", m.name, m.opts.tries)
+}
+
+func (m *Mutex) tryLock(value string) (bool, error) {
+	conn, err := GetConn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	return m.tryLockWith(conn, value)
+}
+
+func (m *Mutex) tryLockWith(conn RedisConn, value string) (bool, error) {
+	reply, err := conn.Do("SET", m.name, value, "NX", "PX", m.opts.expiry.Milliseconds())
+	if err != nil {
+		return false, err
+	}
+	return reply != nil, nil
+}
+
+// Unlock releases the lock, but only if it's still held by this Mutex's
+// token. It reports whether the lock was actually released.
+func (m *Mutex) Unlock() (bool, error) {
+	conn, err := GetConn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	return m.unlockWith(conn)
+}
+
+func (m *Mutex) unlockWith(conn RedisConn) (bool, error) {
+	n, err := Int(unlockScript.Do(conn, m.name, m.value))
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// Extend resets the lock's TTL back to its configured expiry, but only if
+// it's still held by this Mutex's token.
+func (m *Mutex) Extend() (bool, error) {
+	conn, err := GetConn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	return m.extendWith(conn)
+}
+
+func (m *Mutex) extendWith(conn RedisConn) (bool, error) {
+	n, err := Int(extendScript.Do(conn, m.name, m.value, m.opts.expiry.Milliseconds()))
+	if err != nil {
+		return false, err
+	}
+	return n == 1, nil
+}
+
+// randomToken generates the random value SET NX stores at the lock key, used
+// to tell owners apart in the CAS unlock/extend scripts.
+func randomToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// jitter returns a random duration in [d/2, d], spreading out retries from
+// clients contending for the same lock.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(d)/2+1))
+	if err != nil {
+		return d
+	}
+	return d/2 + time.Duration(n.Int64())
+}
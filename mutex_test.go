@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// fakeMutexConn is a minimal RedisConn backed by an in-memory map, just
+// enough to emulate "SET NX PX" and the unlock/extend Lua scripts' CAS
+// semantics (GET == token, then DEL/PEXPIRE) without a live server.
+type fakeMutexConn struct {
+	store map[string]string
+}
+
+func newFakeMutexConn() *fakeMutexConn {
+	return &fakeMutexConn{store: make(map[string]string)}
+}
+
+func (c *fakeMutexConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	switch commandName {
+	case "SET":
+		key, value := args[0].(string), args[1].(string)
+		if _, exists := c.store[key]; exists {
+			return nil, nil // NX: key already set, SET is a no-op
+		}
+		c.store[key] = value
+		return "OK", nil
+
+	case "EVALSHA":
+		hash, key, token := args[0].(string), args[2].(string), args[3].(string)
+		switch hash {
+		case unlockScript.Hash():
+			if c.store[key] != token {
+				return int64(0), nil
+			}
+			delete(c.store, key)
+			return int64(1), nil
+		case extendScript.Hash():
+			if c.store[key] != token {
+				return int64(0), nil
+			}
+			return int64(1), nil
+		default:
+			return nil, errNoScript{}
+		}
+
+	default:
+		return nil, fmt.Errorf("fakeMutexConn: unsupported command %q", commandName)
+	}
+}
+
+func (c *fakeMutexConn) Close() error { return nil }
+func (c *fakeMutexConn) Err() error   { return nil }
+
+func (c *fakeMutexConn) Send(commandName string, args ...interface{}) error {
+	return nil
+}
+
+func (c *fakeMutexConn) Flush() error                  { return nil }
+func (c *fakeMutexConn) Receive() (interface{}, error) { return nil, nil }
+func (c *fakeMutexConn) WithLog() RedisConn            { return c }
+func (c *fakeMutexConn) DoContext(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	return c.Do(commandName, args...)
+}
+func (c *fakeMutexConn) SendContext(ctx context.Context, commandName string, args ...interface{}) error {
+	return c.Send(commandName, args...)
+}
+func (c *fakeMutexConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	return c.Receive()
+}
+
+func TestMutexTryLockUnlockExtend(t *testing.T) {
+	m := NewMutex("resource")
+	conn := newFakeMutexConn()
+
+	ok, err := m.tryLockWith(conn, "token-a")
+	if err != nil || !ok {
+		t.Fatalf("tryLockWith = (%v, %v), want (true, nil)", ok, err)
+	}
+
+	ok, err = m.tryLockWith(conn, "token-b")
+	if err != nil || ok {
+		t.Fatalf("tryLockWith on an already-held key = (%v, %v), want (false, nil)", ok, err)
+	}
+
+	m.value = "wrong-token"
+	ok, err = m.unlockWith(conn)
+	if err != nil || ok {
+		t.Fatalf("unlockWith with the wrong token = (%v, %v), want (false, nil)", ok, err)
+	}
+
+	m.value = "token-a"
+	ok, err = m.extendWith(conn)
+	if err != nil || !ok {
+		t.Fatalf("extendWith with the owning token = (%v, %v), want (true, nil)", ok, err)
+	}
+
+	ok, err = m.unlockWith(conn)
+	if err != nil || !ok {
+		t.Fatalf("unlockWith with the owning token = (%v, %v), want (true, nil)", ok, err)
+	}
+
+	ok, err = m.unlockWith(conn)
+	if err != nil || ok {
+		t.Fatalf("unlockWith after the lock was already released = (%v, %v), want (false, nil)", ok, err)
+	}
+}
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"crypto/tls"
+	"time"
+)
+
+// poolOptions carries the tunable parameters accepted by NewRedisPool,
+// NewRedisPoolSentinel and NewRedisPoolCluster.
+type poolOptions struct {
+	db           int
+	maxIdle      int
+	maxActive    int
+	idleTimeout  time.Duration
+	dialTimeout  time.Duration
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	tlsConfig    *tls.Config
+	username     string
+	wait         bool
+}
+
+func defaultPoolOptions() *poolOptions {
+	return &poolOptions{
+		db:           0,
+		maxIdle:      3,
+		idleTimeout:  240 * time.Second,
+		dialTimeout:  5 * time.Second,
+		readTimeout:  5 * time.Second,
+		writeTimeout: 5 * time.Second,
+	}
+}
+
+// Option configures a redis pool created by NewRedisPool, NewRedisPoolSentinel
+// or NewRedisPoolCluster.
+type Option func(*poolOptions)
+
+// WithDB selects the logical database to SELECT right after connecting.
+// NewRedisPoolCluster does not apply it; cluster node connections stay on
+// database 0.
+func WithDB(db int) Option {
+	return func(o *poolOptions) { o.db = db }
+}
+
+// WithDialTimeout sets the timeout used when establishing new connections.
+func WithDialTimeout(d time.Duration) Option {
+	return func(o *poolOptions) { o.dialTimeout = d }
+}
+
+// WithReadTimeout sets the timeout for socket reads.
+func WithReadTimeout(d time.Duration) Option {
+	return func(o *poolOptions) { o.readTimeout = d }
+}
+
+// WithWriteTimeout sets the timeout for socket writes.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(o *poolOptions) { o.writeTimeout = d }
+}
+
+// WithMaxIdle sets the maximum number of idle connections kept in the pool.
+func WithMaxIdle(n int) Option {
+	return func(o *poolOptions) { o.maxIdle = n }
+}
+
+// WithMaxActive sets the maximum number of connections allocated by the pool
+// at a given time, across both idle and in-use connections. Zero means no limit.
+func WithMaxActive(n int) Option {
+	return func(o *poolOptions) { o.maxActive = n }
+}
+
+// WithIdleTimeout sets how long to keep an idle connection before closing it.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *poolOptions) { o.idleTimeout = d }
+}
+
+// WithTLS enables TLS and dials using the given config.
+func WithTLS(c *tls.Config) Option {
+	return func(o *poolOptions) { o.tlsConfig = c }
+}
+
+// WithUsername sets the username used for Redis 6+ ACL authentication.
+func WithUsername(username string) Option {
+	return func(o *poolOptions) { o.username = username }
+}
+
+// WithWait makes Get block until a connection is available when the pool has
+// already reached MaxActive, instead of returning ErrPoolExhausted.
+func WithWait(wait bool) Option {
+	return func(o *poolOptions) { o.wait = wait }
+}
@@ -0,0 +1,237 @@
+package redis
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/zhufuyi/logger"
+
+	"github.com/gomodule/redigo/redis"
+)
+
+const (
+	pubSubPingInterval = 30 * time.Second
+	pubSubMaxBackoff   = 30 * time.Second
+)
+
+// Message is a single pub/sub notification delivered to a Subscriber channel.
+type Message struct {
+	Channel string // channel the message arrived on
+	Pattern string // pattern that matched, only set for PSubscribe
+	Payload []byte
+}
+
+// Subscriber wraps a redis.PubSubConn and keeps it alive across disconnects,
+// re-subscribing to every previously registered channel/pattern. Pub/sub is
+// the one feature the redigo wrapper in this package didn't expose before.
+type Subscriber struct {
+	msgCh chan Message
+
+	mu       sync.Mutex
+	channels map[string]bool
+	patterns map[string]bool
+	psc      *redis.PubSubConn // current live connection, nil while disconnected
+}
+
+// NewSubscriber creates a Subscriber and starts its background receive loop,
+// which re-subscribes to registered channels/patterns and reconnects with
+// exponential backoff whenever the connection drops. Cancelling ctx stops the
+// loop, unsubscribes and closes the connection.
+func NewSubscriber(ctx context.Context) *Subscriber {
+	s := &Subscriber{
+		msgCh:    make(chan Message),
+		channels: make(map[string]bool),
+		patterns: make(map[string]bool),
+	}
+
+	go s.run(ctx)
+
+	return s
+}
+
+// Subscribe registers channels and returns the channel of Messages the
+// Subscriber delivers all of its subscriptions on. Safe to call repeatedly;
+// new channels are added to whatever connection is currently live.
+func (s *Subscriber) Subscribe(ctx context.Context, channels ...string) (<-chan Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range channels {
+		s.channels[c] = true
+	}
+	if s.psc != nil {
+		if err := s.psc.Subscribe(toInterfaceSlice(channels)...); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.msgCh, nil
+}
+
+// PSubscribe registers patterns and returns the channel of Messages the
+// Subscriber delivers all of its subscriptions on.
+func (s *Subscriber) PSubscribe(ctx context.Context, patterns ...string) (<-chan Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range patterns {
+		s.patterns[p] = true
+	}
+	if s.psc != nil {
+		if err := s.psc.PSubscribe(toInterfaceSlice(patterns)...); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.msgCh, nil
+}
+
+// Publish publishes payload on channel and returns the number of clients that
+// received it.
+func Publish(channel string, payload interface{}) (int, error) {
+	conn, err := GetConn()
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	return Int(conn.Do("PUBLISH", channel, payload))
+}
+
+func (s *Subscriber) run(ctx context.Context) {
+	defer close(s.msgCh)
+
+	backoff := time.Second
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		if err := s.receiveLoop(ctx); err != nil {
+			logger.Error("redis subscriber disconnected, reconnecting", logger.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > pubSubMaxBackoff {
+			backoff = pubSubMaxBackoff
+		}
+	}
+}
+
+// receiveLoop opens one connection, re-subscribes to every registered
+// channel/pattern, then pumps messages until the connection errors or ctx is
+// cancelled.
+func (s *Subscriber) receiveLoop(ctx context.Context) error {
+	conn, err := GetConn()
+	if err != nil {
+		return err
+	}
+	psc := &redis.PubSubConn{Conn: conn}
+	defer psc.Close()
+
+	s.mu.Lock()
+	channels := mapKeys(s.channels)
+	patterns := mapKeys(s.patterns)
+	s.psc = psc
+	var subErr error
+	if len(channels) > 0 {
+		subErr = psc.Subscribe(toInterfaceSlice(channels)...)
+	}
+	if subErr == nil && len(patterns) > 0 {
+		subErr = psc.PSubscribe(toInterfaceSlice(patterns)...)
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.psc = nil
+		s.mu.Unlock()
+	}()
+
+	if subErr != nil {
+		return subErr
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go s.healthCheck(psc, done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			_ = psc.Unsubscribe()
+			_ = psc.PUnsubscribe()
+			s.mu.Unlock()
+			return nil
+		default:
+		}
+
+		switch v := psc.Receive().(type) {
+		case redis.Message:
+			// Pattern is only populated when the message arrived via a
+			// PSubscribe match; redigo has no separate PMessage type.
+			s.deliver(ctx, Message{Channel: v.Channel, Pattern: v.Pattern, Payload: v.Data})
+		case redis.Subscription:
+			// channel/pattern count changed, nothing to deliver
+		case error:
+			return v
+		}
+	}
+}
+
+func (s *Subscriber) deliver(ctx context.Context, msg Message) {
+	select {
+	case s.msgCh <- msg:
+	case <-ctx.Done():
+	}
+}
+
+// healthCheck periodically pings the pub/sub connection so a dead TCP peer is
+// noticed even when no messages are flowing. Ping is a write (Send+Flush)
+// just like Subscribe/PSubscribe, and redigo connections aren't safe for
+// concurrent writers, so it takes s.mu the same way those do to keep the
+// writes serialized.
+func (s *Subscriber) healthCheck(psc *redis.PubSubConn, done <-chan struct{}) {
+	ticker := time.NewTicker(pubSubPingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			err := psc.Ping("")
+			s.mu.Unlock()
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func toInterfaceSlice(ss []string) []interface{} {
+	out := make([]interface{}, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
+
+func mapKeys(m map[string]bool) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
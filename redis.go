@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/zhufuyi/logger"
@@ -11,9 +12,9 @@ import (
 	"go.uber.org/zap"
 )
 
-// 当前应用允许最大连接数，最大不能超过redis极限连接数
+// defaultMaxActiveCount 当前应用允许最大连接数，最大不能超过redis极限连接数
 // 如果多个项目共用同一个redis，要考虑每个项目限制连接数，防止过量连接造成redis卡死
-var maxActiveCount = 2800 // 经过压测得到大概结果
+const defaultMaxActiveCount = 2800 // 经过压测得到大概结果
 
 var pool *RedisPool
 
@@ -24,8 +25,8 @@ func GetConn() (RedisConn, error) {
 	}
 
 	// 超出redis承受的极限最大连接数，直接拦截，并返回错误
-	if pool.ActiveCount() > maxActiveCount {
-		return nil, fmt.Errorf("redis connect clients exceeded the limit of %d", maxActiveCount)
+	if pool.ActiveCount() > pool.maxActiveCount {
+		return nil, fmt.Errorf("redis connect clients exceeded the limit of %d", pool.maxActiveCount)
 	}
 
 	return pool.Get(), nil
@@ -33,6 +34,7 @@ func GetConn() (RedisConn, error) {
 
 type RedisPool struct {
 	redis.Pool
+	maxActiveCount int // 单个连接池允许的最大连接数上限，与Pool.MaxActive相互独立
 }
 
 func (r *RedisPool) Get() RedisConn {
@@ -43,11 +45,36 @@ func (r *RedisPool) Get() RedisConn {
 type RedisConn interface {
 	redis.Conn
 	WithLog() RedisConn
+
+	// DoContext, SendContext and ReceiveContext are the context-aware
+	// counterparts of Do, Send and Receive: a ctx deadline becomes a
+	// per-command socket deadline, and cancelling ctx aborts an in-flight
+	// blocking command (BLPOP, XREAD, SUBSCRIBE, ...) by closing the
+	// connection.
+	DoContext(ctx context.Context, commandName string, args ...interface{}) (interface{}, error)
+	SendContext(ctx context.Context, commandName string, args ...interface{}) error
+	ReceiveContext(ctx context.Context) (interface{}, error)
 }
 
 type DefaultRedisConn struct {
 	redis.Conn
 	printLog bool
+
+	// contextStop, when set, stops the watchContext goroutine GetConnContext
+	// started for this connection. Close calls it so that goroutine doesn't
+	// outlive the connection when the caller finishes with it well before
+	// its context is done.
+	contextStop func()
+}
+
+// Close releases the connection back to the pool, also stopping any
+// watchContext goroutine GetConnContext started for it.
+func (d *DefaultRedisConn) Close() error {
+	if d.contextStop != nil {
+		d.contextStop()
+		d.contextStop = nil
+	}
+	return d.Conn.Close()
 }
 
 func (d *DefaultRedisConn) WithLog() RedisConn {
@@ -56,40 +83,12 @@ func (d *DefaultRedisConn) WithLog() RedisConn {
 }
 
 func (d *DefaultRedisConn) Do(commandName string, args ...interface{}) (reply interface{}, err error) {
-	result, err := d.Conn.Do(commandName, args...)
-	if err != nil {
-		if d.printLog {
-			d.printLog = false
-			printError(err, "redis do error", commandName, args...)
-		}
-		return result, err
-	}
-
-	if d.printLog {
-		d.printLog = false
-		printInfo(result, "redis do", commandName, args...)
-	}
-
-	return result, err
+	return d.DoContext(context.Background(), commandName, args...)
 }
 
 // Send writes the command to the client's output buffer.
 func (d *DefaultRedisConn) Send(commandName string, args ...interface{}) error {
-	err := d.Conn.Send(commandName, args...)
-	if err != nil {
-		if d.printLog {
-			d.printLog = false
-			printError(err, "redis send error", commandName, args...)
-		}
-		return err
-	}
-
-	if d.printLog {
-		d.printLog = false
-		printInfo(nil, "redis send", commandName, args...)
-	}
-
-	return err
+	return d.SendContext(context.Background(), commandName, args...)
 }
 
 // Flush flushes the output buffer to the Redis server.
@@ -108,20 +107,7 @@ func (d *DefaultRedisConn) Flush() error {
 
 // Receive receives a single reply from the Redis server
 func (d *DefaultRedisConn) Receive() (reply interface{}, err error) {
-	result, err := d.Conn.Receive()
-	if err != nil {
-		if d.printLog {
-			d.printLog = false
-			printError(err, "redis receive error", "")
-		}
-		return result, err
-	}
-
-	if d.printLog {
-		d.printLog = false
-		printInfo(result, "redis receive", "")
-	}
-	return result, err
+	return d.ReceiveContext(context.Background())
 }
 
 // 转换类型
@@ -181,24 +167,37 @@ func printInfo(result interface{}, msg string, commandName string, args ...inter
 }
 
 // NewRedisPool connect redis，if test ping failed，return error
-func NewRedisPool(server, password string) error {
+func NewRedisPool(server, password string, opts ...Option) error {
+	o := defaultPoolOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	dialOpts := []redis.DialOption{
+		redis.DialDatabase(o.db),
+		redis.DialConnectTimeout(o.dialTimeout),
+		redis.DialReadTimeout(o.readTimeout),
+		redis.DialWriteTimeout(o.writeTimeout),
+	}
+	if password != "" {
+		dialOpts = append(dialOpts, redis.DialPassword(password))
+	}
+	if o.username != "" {
+		dialOpts = append(dialOpts, redis.DialUsername(o.username))
+	}
+	if o.tlsConfig != nil {
+		dialOpts = append(dialOpts, redis.DialUseTLS(true), redis.DialTLSConfig(o.tlsConfig))
+	}
+
 	pool = &RedisPool{
+		maxActiveCount: defaultMaxActiveCount,
 		Pool: redis.Pool{
-			MaxIdle:     3,
-			IdleTimeout: 240 * time.Second,
+			MaxIdle:     o.maxIdle,
+			MaxActive:   o.maxActive,
+			IdleTimeout: o.idleTimeout,
+			Wait:        o.wait,
 			Dial: func() (redis.Conn, error) {
-				c, err := redis.Dial("tcp", server)
-				if err != nil {
-					return nil, err
-				}
-
-				if _, err = c.Do("AUTH", password); err != nil {
-					c.Close()
-					return nil, err
-				}
-
-				c.Do("select", 0)
-				return c, err
+				return redis.Dial("tcp", server, dialOpts...)
 			},
 
 			TestOnBorrow: func(c redis.Conn, t time.Time) error {
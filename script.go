@@ -0,0 +1,80 @@
+package redis
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+)
+
+// Script represents a Lua script, mirroring redigo's redis.Script helper.
+// It caches the script's SHA1 so repeated calls can use the cheaper EVALSHA
+// command, falling back to EVAL the first time (or after a NOSCRIPT error,
+// e.g. because the server restarted and flushed its script cache).
+type Script struct {
+	keyCount int
+	src      string
+	hash     string
+}
+
+// NewScript creates a Script. keyCount is the number of keys the script
+// expects (the KEYS table); it's passed ahead of ARGV on every call.
+func NewScript(keyCount int, src string) *Script {
+	h := sha1.Sum([]byte(src))
+	return &Script{
+		keyCount: keyCount,
+		src:      src,
+		hash:     hex.EncodeToString(h[:]),
+	}
+}
+
+// Hash returns the SHA1 of the script's source, as used by EVALSHA.
+func (s *Script) Hash() string {
+	return s.hash
+}
+
+// args builds the "<spec> <keyCount> KEYS... ARGV..." argument list, where
+// spec is either the script source (EVAL) or its hash (EVALSHA).
+func (s *Script) args(spec interface{}, keysAndArgs []interface{}) []interface{} {
+	args := make([]interface{}, 0, len(keysAndArgs)+2)
+	args = append(args, spec, s.keyCount)
+	args = append(args, keysAndArgs...)
+	return args
+}
+
+// Do evaluates the script, first trying EVALSHA and transparently falling
+// back to EVAL when the server doesn't have the script cached yet.
+func (s *Script) Do(conn RedisConn, keysAndArgs ...interface{}) (interface{}, error) {
+	reply, err := conn.Do("EVALSHA", s.args(s.hash, keysAndArgs)...)
+	if err != nil && isNoScript(err) {
+		reply, err = conn.Do("EVAL", s.args(s.src, keysAndArgs)...)
+	}
+	return reply, err
+}
+
+// Send writes the script invocation to conn's output buffer using EVALSHA.
+// Because Send doesn't read a reply, a NOSCRIPT error can't be detected here;
+// use Load beforehand (or SendHash within a MULTI/EXEC, preceded by a Load
+// earlier in the connection's lifetime) to be sure the hash is cached.
+func (s *Script) Send(conn RedisConn, keysAndArgs ...interface{}) error {
+	return conn.Send("EVALSHA", s.args(s.hash, keysAndArgs)...)
+}
+
+// SendHash is an alias of Send kept for readability at call sites that mix
+// Send and SendHash, e.g. inside pipelines where the script was already
+// Load-ed onto the connection.
+func (s *Script) SendHash(conn RedisConn, keysAndArgs ...interface{}) error {
+	return s.Send(conn, keysAndArgs...)
+}
+
+// Load uploads the script to the server via SCRIPT LOAD so a later
+// Send/SendHash (which can't fall back on NOSCRIPT) is guaranteed to hit.
+func (s *Script) Load(conn RedisConn) error {
+	_, err := conn.Do("SCRIPT", "LOAD", s.src)
+	return err
+}
+
+// isNoScript reports whether err is the "NOSCRIPT No matching script..."
+// error redis returns when EVALSHA references an unknown hash.
+func isNoScript(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT")
+}
@@ -0,0 +1,64 @@
+package redis
+
+import (
+	"context"
+	"testing"
+)
+
+// errNoScript mimics the error string redis.Error carries for a NOSCRIPT
+// reply, which is all isNoScript inspects.
+type errNoScript struct{}
+
+func (errNoScript) Error() string { return "NOSCRIPT No matching script. Please use EVAL." }
+
+// fakeScriptConn is a minimal RedisConn that only understands EVALSHA/EVAL,
+// enough to exercise Script.Do's fallback without a live server.
+type fakeScriptConn struct {
+	calls []string
+}
+
+func (c *fakeScriptConn) Do(commandName string, args ...interface{}) (interface{}, error) {
+	c.calls = append(c.calls, commandName)
+	if commandName == "EVALSHA" {
+		return nil, errNoScript{}
+	}
+	return "ok", nil
+}
+
+func (c *fakeScriptConn) Close() error { return nil }
+func (c *fakeScriptConn) Err() error   { return nil }
+
+func (c *fakeScriptConn) Send(commandName string, args ...interface{}) error {
+	return nil
+}
+
+func (c *fakeScriptConn) Flush() error                  { return nil }
+func (c *fakeScriptConn) Receive() (interface{}, error) { return nil, nil }
+func (c *fakeScriptConn) WithLog() RedisConn            { return c }
+func (c *fakeScriptConn) DoContext(ctx context.Context, commandName string, args ...interface{}) (interface{}, error) {
+	return c.Do(commandName, args...)
+}
+func (c *fakeScriptConn) SendContext(ctx context.Context, commandName string, args ...interface{}) error {
+	return c.Send(commandName, args...)
+}
+func (c *fakeScriptConn) ReceiveContext(ctx context.Context) (interface{}, error) {
+	return c.Receive()
+}
+
+func TestScriptDoFallsBackToEvalOnNoScript(t *testing.T) {
+	s := NewScript(1, "return 1")
+	conn := &fakeScriptConn{}
+
+	reply, err := s.Do(conn, "key1", "arg1")
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	if reply != "ok" {
+		t.Fatalf("Do returned %v, want %q", reply, "ok")
+	}
+
+	want := []string{"EVALSHA", "EVAL"}
+	if len(conn.calls) != len(want) || conn.calls[0] != want[0] || conn.calls[1] != want[1] {
+		t.Fatalf("conn.calls = %v, want %v (EVALSHA then fallback to EVAL)", conn.calls, want)
+	}
+}
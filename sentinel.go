@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/FZambia/sentinel"
+	"github.com/gomodule/redigo/redis"
+)
+
+// NewRedisPoolSentinel connects to a master monitored by Redis Sentinel.
+// masterName is the name configured on the sentinels (monitor <masterName> ...),
+// sentinelAddrs is the list of sentinel "host:port" addresses. The pool always
+// dials the current master and re-resolves it whenever TestOnBorrow finds that
+// a borrowed connection no longer points at a master, e.g. right after a
+// failover.
+func NewRedisPoolSentinel(masterName string, sentinelAddrs []string, password string, opts ...Option) error {
+	o := defaultPoolOptions()
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	sntnl := &sentinel.Sentinel{
+		Addrs:      sentinelAddrs,
+		MasterName: masterName,
+		Dial: func(addr string) (redis.Conn, error) {
+			return redis.DialTimeout("tcp", addr, o.dialTimeout, o.readTimeout, o.writeTimeout)
+		},
+	}
+
+	pool = &RedisPool{
+		maxActiveCount: defaultMaxActiveCount,
+		Pool: redis.Pool{
+			MaxIdle:     o.maxIdle,
+			MaxActive:   o.maxActive,
+			IdleTimeout: o.idleTimeout,
+			Wait:        o.wait,
+			Dial: func() (redis.Conn, error) {
+				masterAddr, err := sntnl.MasterAddr()
+				if err != nil {
+					return nil, err
+				}
+
+				dialOpts := []redis.DialOption{
+					redis.DialDatabase(o.db),
+					redis.DialConnectTimeout(o.dialTimeout),
+					redis.DialReadTimeout(o.readTimeout),
+					redis.DialWriteTimeout(o.writeTimeout),
+				}
+				if password != "" {
+					dialOpts = append(dialOpts, redis.DialPassword(password))
+				}
+				if o.username != "" {
+					dialOpts = append(dialOpts, redis.DialUsername(o.username))
+				}
+				if o.tlsConfig != nil {
+					dialOpts = append(dialOpts, redis.DialUseTLS(true), redis.DialTLSConfig(o.tlsConfig))
+				}
+
+				return redis.Dial("tcp", masterAddr, dialOpts...)
+			},
+			TestOnBorrow: func(c redis.Conn, t time.Time) error {
+				if !sentinel.TestRole(c, "master") {
+					return fmt.Errorf("redis: sentinel ROLE check failed, connection is not a master")
+				}
+				return nil
+			},
+		},
+	}
+
+	rconn, _ := GetConn()
+	defer rconn.Close()
+
+	_, err := rconn.Do("PING")
+	return err
+}